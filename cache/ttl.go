@@ -0,0 +1,104 @@
+// Package cache provides a small in-memory TTL/LRU cache used to avoid
+// re-parsing tokens, public keys, and thumbnail configs on every request.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a bounded, expiring key/value store.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	Delete(key string)
+	// Stats returns the cumulative hit/miss counters.
+	Stats() (hits, misses uint64)
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// ttlLRU is a Cache backed by an LRU eviction list with per-entry
+// expiry. It is safe for concurrent use.
+type ttlLRU struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	size   int
+	items  map[string]*list.Element
+	order  *list.List
+	hits   uint64
+	misses uint64
+}
+
+// NewTTL creates a Cache that evicts entries older than ttl, bounded to
+// size entries (least-recently-used first). size <= 0 means unbounded.
+func NewTTL(ttl time.Duration, size int) Cache {
+	return &ttlLRU{
+		ttl:   ttl,
+		size:  size,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+func (c *ttlLRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if c.ttl > 0 && time.Now().After(e.expiresAt) {
+		c.removeElement(el)
+		c.misses++
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.hits++
+	return e.value, true
+}
+
+func (c *ttlLRU) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	e := &entry{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	el := c.order.PushFront(e)
+	c.items[key] = el
+	if c.size > 0 && c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *ttlLRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *ttlLRU) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *ttlLRU) removeElement(el *list.Element) {
+	e := el.Value.(*entry)
+	delete(c.items, e.key)
+	c.order.Remove(el)
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"flag"
+	"time"
+)
+
+// ServeFlags holds the command-line flags accepted by `chanify serve`.
+type ServeFlags struct {
+	Listen    string
+	ACMEHosts acmeHostList
+	ACMEEmail string
+	ACMECache string
+
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCClaimField   string
+
+	CacheTTL  time.Duration
+	CacheSize int
+}
+
+// acmeHostList collects repeated --acme-host flags into a []string.
+type acmeHostList []string
+
+func (l *acmeHostList) String() string {
+	return ""
+}
+
+func (l *acmeHostList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// ParseServeFlags parses the flags `chanify serve` accepts, including
+// --acme-host (repeatable), --acme-email, and --acme-cache-dir which
+// together enable built-in ACME/Let's Encrypt TLS.
+func ParseServeFlags(fs *flag.FlagSet, args []string) (*ServeFlags, error) {
+	f := &ServeFlags{}
+	fs.StringVar(&f.Listen, "listen", ":8080", "address to listen on when ACME is not enabled")
+	fs.Var(&f.ACMEHosts, "acme-host", "hostname to obtain an ACME certificate for (repeatable)")
+	fs.StringVar(&f.ACMEEmail, "acme-email", "", "contact email registered with the ACME CA")
+	fs.StringVar(&f.ACMECache, "acme-cache-dir", "acme-cache", "directory (relative to the data dir) used to cache ACME certificates")
+	fs.StringVar(&f.OIDCIssuerURL, "oidc-issuer", "", "OIDC issuer URL, enables OIDC login for the web console")
+	fs.StringVar(&f.OIDCClientID, "oidc-client-id", "", "OIDC client id")
+	fs.StringVar(&f.OIDCClientSecret, "oidc-client-secret", "", "OIDC client secret")
+	fs.StringVar(&f.OIDCRedirectURL, "oidc-redirect-url", "", "OIDC redirect URL, should point at /oidc/callback")
+	fs.StringVar(&f.OIDCClaimField, "oidc-claim-field", "sub", "claim mapped to the chanify user, \"sub\" or \"email\"")
+	fs.DurationVar(&f.CacheTTL, "cache-ttl", defaultCacheTTL, "TTL for the token/key/thumbnail caches")
+	fs.IntVar(&f.CacheSize, "cache-size", 4096, "max entries per token/key/thumbnail cache")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ToACMEConfig builds an *ACMEConfig from the parsed flags, or nil when
+// ACME wasn't requested.
+func (f *ServeFlags) ToACMEConfig(dataDir string) *ACMEConfig {
+	if len(f.ACMEHosts) == 0 {
+		return nil
+	}
+	return &ACMEConfig{
+		Hosts:    f.ACMEHosts,
+		Email:    f.ACMEEmail,
+		CacheDir: dataDir + "/" + f.ACMECache,
+	}
+}
+
+// ToOIDCConfig builds an *OIDCConfig from the parsed flags, or nil when
+// OIDC wasn't requested.
+func (f *ServeFlags) ToOIDCConfig() *OIDCConfig {
+	if len(f.OIDCIssuerURL) == 0 {
+		return nil
+	}
+	return &OIDCConfig{
+		IssuerURL:    f.OIDCIssuerURL,
+		ClientID:     f.OIDCClientID,
+		ClientSecret: f.OIDCClientSecret,
+		RedirectURL:  f.OIDCRedirectURL,
+		ClaimField:   f.OIDCClaimField,
+		SessionTTL:   24 * time.Hour,
+	}
+}
+
+// ToCacheConfig builds a *CacheConfig from the parsed flags.
+func (f *ServeFlags) ToCacheConfig() *CacheConfig {
+	return &CacheConfig{TTL: f.CacheTTL, Size: f.CacheSize}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+// imageHashLen is the length of a hex-encoded SHA-256 digest, the only
+// shape a content-addressed image hash is allowed to take before it
+// reaches a filesystem path.
+const imageHashLen = 64
+
+// isValidImageHash reports whether hash looks like a hex-encoded
+// SHA-256 digest, rejecting anything (path separators, "..", wrong
+// length) before it's joined into a filesystem path.
+func isValidImageHash(hash string) bool {
+	if len(hash) != imageHashLen {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// identityImageDir returns the content-addressed storage path for an
+// image hash, sharded by its first two hex characters.
+func (c *Core) identityImageDir(hash string) string {
+	return filepath.Join(c.dataDir, "images", hash[:2])
+}
+
+// storeIdentityImage persists img on disk, keyed by its content hash so
+// identical uploads across users are stored only once.
+func (c *Core) storeIdentityImage(img *model.IdentityImage) (string, error) {
+	hash := img.Hash()
+	dir := c.identityImageDir(hash)
+	path := filepath.Join(dir, hash)
+	if err := writeFileIfAbsent(dir, path, img.Payload); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (c *Core) loadIdentityImage(hash string) ([]byte, error) {
+	if !isValidImageHash(hash) {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(filepath.Join(c.identityImageDir(hash), hash))
+}
+
+func writeFileIfAbsent(dir, path string, data []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0o644)
+}
+
+// handleGetIdentityImage returns an IdentityImage as JSON with its
+// payload inlined as a data: URI, so embedded UIs can fetch an avatar in
+// a single request.
+func (c *Core) handleGetIdentityImage(ctx *gin.Context) {
+	hash := ctx.Param("hash")
+	if !isValidImageHash(hash) {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	payload, err := c.loadIdentityImage(hash)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	// Go through the chunk0-5 thumbnail cache instead of
+	// model.NewIdentityImage's built-in decode, so repeated avatar
+	// fetches don't re-decode the image header every time.
+	thumb, contentType := createThumbnail(payload)
+	img := &model.IdentityImage{Name: hash, Payload: payload, FileSize: len(payload)}
+	if thumb != nil {
+		img.Width = int(thumb.Width)
+		img.Height = int(thumb.Height)
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"hash":     hash,
+		"type":     contentType,
+		"width":    img.Width,
+		"height":   img.Height,
+		"dataURI":  img.GetDataURI(),
+		"fileSize": img.FileSize,
+	})
+}
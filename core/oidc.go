@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig configures the web console as an OIDC relying party. It is
+// optional and coexists with the existing CHUserSign/CHDevSign headers
+// used by mobile clients.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	ClaimField   string        // "sub" or "email", defaults to "sub"
+	SessionTTL   time.Duration // lifetime of the issued session cookie
+}
+
+// oidcAuth holds the resolved provider/verifier for an OIDCConfig.
+type oidcAuth struct {
+	cfg      *OIDCConfig
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth    oauth2.Config
+}
+
+func newOIDCAuth(ctx context.Context, cfg *OIDCConfig) (*oidcAuth, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	claim := cfg.ClaimField
+	if len(claim) == 0 {
+		claim = "sub"
+	}
+	cfg.ClaimField = claim
+	return &oidcAuth{
+		cfg:      cfg,
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+	}, nil
+}
+
+// setupOIDC enables the OIDC relying-party subsystem when cfg is set.
+func (c *Core) setupOIDC(ctx context.Context, cfg *OIDCConfig) error {
+	if cfg == nil || len(cfg.IssuerURL) == 0 {
+		return nil
+	}
+	auth, err := newOIDCAuth(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	c.oidc = auth
+	return nil
+}
+
+// handleOIDCLogin starts the auth-code-with-PKCE flow.
+func (c *Core) handleOIDCLogin(ctx *gin.Context) {
+	verifier := oauth2.GenerateVerifier()
+	state := randomState()
+	ctx.SetCookie("oidc_state", state, 300, "/", "", true, true)
+	ctx.SetCookie("oidc_verifier", verifier, 300, "/", "", true, true)
+	url := c.oidc.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// handleOIDCCallback validates the ID token and issues a session cookie
+// for the authenticated chanify user. It issues no device token; only
+// the session cookie is bound to the OIDC login.
+func (c *Core) handleOIDCCallback(ctx *gin.Context) {
+	state, _ := ctx.Cookie("oidc_state")
+	if len(state) == 0 || ctx.Query("state") != state {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	verifier, _ := ctx.Cookie("oidc_verifier")
+	token, err := c.oidc.oauth.Exchange(ctx.Request.Context(), ctx.Query("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	idToken, err := c.oidc.verifier.Verify(ctx.Request.Context(), rawIDToken)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	user, _ := claims[c.oidc.cfg.ClaimField].(string)
+	if len(user) == 0 {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	session := c.logic.IssueSession(user, c.oidc.cfg.SessionTTL)
+	ctx.SetCookie("ch_session", session, int(c.oidc.cfg.SessionTTL.Seconds()), "/", "", true, true)
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// requireOIDCOrSigned allows either an OIDC session cookie or the
+// existing signed-header auth, so mobile clients remain unaffected.
+func (c *Core) requireOIDCOrSigned(key string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if verifyUser(ctx, key) || verifyDevice(ctx, key) {
+			ctx.Next()
+			return
+		}
+		if c.oidc != nil {
+			if session, err := ctx.Cookie("ch_session"); err == nil && c.logic.VerifySession(session) {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+func randomState() string {
+	b := make([]byte, 16)
+	rand.Read(b) // nolint: errcheck
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerRoutes wires every REST/WebSocket endpoint onto r. It is built
+// up incrementally as subsystems (OIDC, live-tail, identity images, ...)
+// are enabled.
+func (c *Core) registerRoutes(r *gin.Engine) {
+	r.GET("/health", c.handleHealth)
+	if c.oidc != nil {
+		r.GET("/oidc/login", c.handleOIDCLogin)
+		r.GET("/oidc/callback", c.handleOIDCCallback)
+	}
+	r.GET("/rest/v1/tail", func(ctx *gin.Context) {
+		tk, err := c.parseToken(getToken(ctx))
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.handleTail(ctx, tk.GetNodeID())
+	})
+	r.POST("/rest/v1/upload/encrypted", c.handleUploadEncrypted)
+	r.GET("/rest/v1/identity/:hash", c.handleGetIdentityImage)
+}
+
+// handleHealth reports basic liveness plus ACME certificate status for
+// each configured host, so operators can watch renewal without an
+// external reverse proxy.
+func (c *Core) handleHealth(ctx *gin.Context) {
+	resp := gin.H{"status": "ok", "cache": cacheMetrics()}
+	if c.acme != nil {
+		hosts := gin.H{}
+		for _, h := range c.acme.hosts {
+			hosts[h] = c.acme.Status(ctx.Request.Context(), h)
+		}
+		resp["acme"] = hosts
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Serve builds the gin engine, registers all routes, and starts
+// listening. When flags name at least one --acme-host it also starts the :80 HTTP-01
+// challenge handler and serves HTTPS on :443 with certificates obtained
+// and renewed automatically via ACME; otherwise it listens on
+// flags.Listen.
+func (c *Core) Serve(ctx context.Context, flags *ServeFlags) error {
+	setupCache(flags.ToCacheConfig())
+	c.setupACME(flags.ToACMEConfig(c.dataDir))
+	c.setupTail()
+	if err := c.setupOIDC(ctx, flags.ToOIDCConfig()); err != nil {
+		return err
+	}
+
+	r := gin.New()
+	c.registerRoutes(r)
+
+	if c.acme == nil {
+		return http.ListenAndServe(flags.Listen, r)
+	}
+
+	go func() {
+		log.Println("acme: serving HTTP-01 challenges on :80")
+		if err := http.ListenAndServe(":80", c.acme.HTTPHandler(nil)); err != nil {
+			log.Println("acme: http challenge listener stopped:", err)
+		}
+	}()
+
+	srv := &http.Server{
+		Addr:      ":443",
+		Handler:   r,
+		TLSConfig: c.acme.TLSConfig(),
+	}
+	return srv.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,345 @@
+package core
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	tailReplaySize    = 50
+	tailIdleTimeout   = 5 * time.Minute
+	tailPingInterval  = 30 * time.Second
+	tailSupersededMsg = "superseded"
+	// tailRingIdleTTL bounds how long a replay ring for a (user, channel)
+	// pair is kept once it has no active session, so tailHub.ring doesn't
+	// grow without bound over the life of the process.
+	tailRingIdleTTL = 10 * time.Minute
+	tailSweepPeriod = time.Minute
+)
+
+var tailUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// tailControl is the JSON control message sent by the client over the
+// /rest/v1/tail socket.
+type tailControl struct {
+	Action  string `json:"action"` // "start_streaming" or "stop_streaming"
+	Channel string `json:"channel"`
+}
+
+// tailConn serializes writes to a single underlying WebSocket connection.
+// gorilla/websocket requires at most one concurrent writer: without this,
+// pumpTail's WriteJSON and supersede's/pingLoop's WriteControl calls can
+// race directly on the wire.
+type tailConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (tc *tailConn) WriteJSON(v interface{}) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.conn.WriteJSON(v)
+}
+
+func (tc *tailConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.conn.WriteControl(messageType, data, deadline)
+}
+
+func (tc *tailConn) Close() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.conn.Close()
+}
+
+// tailSession streams messages for a single (user, channel) pair.
+type tailSession struct {
+	conn      *tailConn
+	send      chan *model.Message
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// stop closes closeCh exactly once, however many of Stop/supersede reach
+// it, so pumpTail's select is always guaranteed to return.
+func (s *tailSession) stop() {
+	s.closeOnce.Do(func() { close(s.closeCh) })
+}
+
+// tailHub fans out messages to active tail sessions and enforces the
+// "actor override" rule: only one live session per (user, channel).
+type tailHub struct {
+	mu       sync.Mutex
+	sessions map[string]*tailSession
+	ring     map[string]*ringBuffer
+}
+
+func newTailHub() *tailHub {
+	h := &tailHub{
+		sessions: map[string]*tailSession{},
+		ring:     map[string]*ringBuffer{},
+	}
+	go h.sweepLoop()
+	return h
+}
+
+// sweepLoop periodically evicts replay rings that have had no active
+// session and no new message for tailRingIdleTTL, so the ring map
+// doesn't grow unbounded for channels nobody is tailing anymore.
+func (h *tailHub) sweepLoop() {
+	ticker := time.NewTicker(tailSweepPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.sweep()
+	}
+}
+
+func (h *tailHub) sweep() {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, buf := range h.ring {
+		if h.sessions[key] == nil && now.Sub(buf.LastActive()) > tailRingIdleTTL {
+			delete(h.ring, key)
+		}
+	}
+}
+
+// setupTail installs the live-tail fan-out hub and hooks it into the
+// message pipeline so every sent message is offered to active sessions.
+func (c *Core) setupTail() {
+	c.tail = newTailHub()
+	model.OnMessageSent = func(msg *model.Message) {
+		c.tail.Publish(msg.GetFrom(), msg.GetChannel(), msg)
+	}
+}
+
+func tailKey(user, channel string) string {
+	return user + "\x00" + channel
+}
+
+// Publish is called from the message pipeline whenever a message is sent
+// for channel, fanning it out to any live tail session for user.
+func (h *tailHub) Publish(user, channel string, msg *model.Message) {
+	key := tailKey(user, channel)
+	h.mu.Lock()
+	// Only record into a ring that a session has already created by
+	// subscribing at least once; otherwise channels nobody ever tails
+	// would grow h.ring forever.
+	buf, ok := h.ring[key]
+	s := h.sessions[key]
+	h.mu.Unlock()
+	if ok {
+		buf.Push(msg)
+	}
+	if s != nil {
+		select {
+		case s.send <- msg:
+		default:
+		}
+	}
+}
+
+// Start opens a new tail session for (user, channel), gracefully closing
+// any existing session for the same pair first so stale tabs don't pile
+// up, then replays the last N buffered messages. The replay send is
+// non-blocking, matching Publish, because the caller only starts the
+// pump goroutine that drains s.send after Start returns.
+func (h *tailHub) Start(user, channel string, conn *tailConn) *tailSession {
+	key := tailKey(user, channel)
+	s := &tailSession{conn: conn, send: make(chan *model.Message, tailReplaySize), closeCh: make(chan struct{})}
+	h.mu.Lock()
+	if prev, ok := h.sessions[key]; ok {
+		go prev.supersede()
+	}
+	h.sessions[key] = s
+	buf, ok := h.ring[key]
+	if !ok {
+		buf = newRingBuffer(tailReplaySize)
+		h.ring[key] = buf
+	} else {
+		buf.Touch()
+	}
+	h.mu.Unlock()
+	for _, msg := range buf.Snapshot() {
+		select {
+		case s.send <- msg:
+		default:
+		}
+	}
+	return s
+}
+
+// Stop unregisters s and unblocks its pumpTail goroutine by closing
+// closeCh. It deliberately doesn't touch s.conn: Stop also runs when a
+// client switches channels on the same socket (handleTail's
+// "start_streaming" case), where the connection is handed off to a new
+// session and must stay open.
+func (h *tailHub) Stop(user, channel string, s *tailSession) {
+	key := tailKey(user, channel)
+	h.mu.Lock()
+	if h.sessions[key] == s {
+		delete(h.sessions, key)
+	}
+	h.mu.Unlock()
+	s.stop()
+}
+
+// supersede forcibly closes the connection of a session that's been
+// replaced by a newer one for the same (user, channel) pair. Unlike
+// Stop, it also tears down the connection itself, since this session
+// "owns" a now-stale socket nobody else will reuse.
+func (s *tailSession) supersede() {
+	_ = s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(4000, tailSupersededMsg), time.Now().Add(time.Second))
+	s.stop()
+	s.conn.Close() // nolint: errcheck
+}
+
+// handleTail upgrades the request to a WebSocket and streams messages for
+// the requested channel until the client disconnects, sends
+// stop_streaming, or the socket is superseded by a newer session.
+func (c *Core) handleTail(ctx *gin.Context, user string) {
+	wsConn, err := tailUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	defer wsConn.Close() // nolint: errcheck
+	conn := &tailConn{conn: wsConn}
+
+	var session *tailSession
+	var channel string
+	wsConn.SetReadDeadline(time.Now().Add(tailIdleTimeout)) // nolint: errcheck
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(time.Now().Add(tailIdleTimeout))
+	})
+
+	defer func() {
+		if session != nil {
+			c.tail.Stop(user, channel, session)
+		}
+	}()
+
+	done := make(chan struct{})
+	go c.pingLoop(conn, done)
+	defer close(done)
+
+	for {
+		var ctrl tailControl
+		if err := wsConn.ReadJSON(&ctrl); err != nil {
+			return
+		}
+		switch ctrl.Action {
+		case "start_streaming":
+			if session != nil {
+				c.tail.Stop(user, channel, session)
+			}
+			channel = ctrl.Channel
+			session = c.tail.Start(user, channel, conn)
+			go c.pumpTail(session)
+		case "stop_streaming":
+			if session != nil {
+				c.tail.Stop(user, channel, session)
+				session = nil
+			}
+		}
+	}
+}
+
+// pumpTail drains s.send until either s.closeCh is closed (by Stop, when
+// the client switches/stops streaming, or by supersede, when a newer
+// session replaces this one) or s.send itself is closed.
+func (c *Core) pumpTail(s *tailSession) {
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case msg, ok := <-s.send:
+			if !ok {
+				return
+			}
+			if err := s.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Core) pingLoop(conn *tailConn, done <-chan struct{}) {
+	ticker := time.NewTicker(tailPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ringBuffer is a small fixed-size replay buffer of recent messages.
+type ringBuffer struct {
+	mu         sync.Mutex
+	buf        []*model.Message
+	next       int
+	full       bool
+	lastActive time.Time
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]*model.Message, size), lastActive: time.Now()}
+}
+
+func (r *ringBuffer) Push(msg *model.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.next] = msg
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.lastActive = time.Now()
+}
+
+// LastActive returns when the ring last received a message or was
+// (re)created/touched by a subscribing session.
+func (r *ringBuffer) LastActive() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastActive
+}
+
+// Touch marks the ring as active without pushing a message, e.g. when a
+// session (re)subscribes to an existing ring.
+func (r *ringBuffer) Touch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastActive = time.Now()
+}
+
+func (r *ringBuffer) Snapshot() []*model.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		out := make([]*model.Message, r.next)
+		copy(out, r.buf[:r.next])
+		return out
+	}
+	out := make([]*model.Message, len(r.buf))
+	copy(out, r.buf[r.next:])
+	copy(out[len(r.buf)-r.next:], r.buf[:r.next])
+	return out
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/chanify/chanify/crypto"
+	"github.com/chanify/chanify/model"
+	"github.com/gin-gonic/gin"
+)
+
+// resolveEnvelopeSigner looks up the base64-encoded public key
+// registered for kid (the envelope header's KeyUID) and decodes it into
+// a verifier, going through the same keyCache chunk0-5 added for
+// verifySign so repeated uploads from one device don't re-decode its
+// key every time.
+func (c *Core) resolveEnvelopeSigner(kid string) (model.EnvelopeVerifier, bool) {
+	b64Key, ok := c.logic.DeviceKey(kid)
+	if !ok {
+		return nil, false
+	}
+	pk, ok := keyCache.Get(b64Key)
+	if !ok {
+		kd, err := crypto.Base64Encode.DecodeString(b64Key)
+		if err != nil {
+			return nil, false
+		}
+		loaded, err := crypto.LoadPublicKey(kd)
+		if err != nil {
+			return nil, false
+		}
+		pk = loaded
+		keyCache.Set(b64Key, pk)
+	}
+	verifier, ok := pk.(model.EnvelopeVerifier)
+	return verifier, ok
+}
+
+// handleUploadEncrypted accepts an already-encrypted attachment: the
+// envelope header travels in the X-Envelope header (base64), the
+// ciphertext is the request body. The signature is checked without ever
+// decrypting the body, then the ciphertext is stored content-addressed
+// by its hash.
+func (c *Core) handleUploadEncrypted(ctx *gin.Context) {
+	header, err := base64.StdEncoding.DecodeString(ctx.GetHeader("X-Envelope"))
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	envHeader, err := model.ParseEnvelopeHeader(header)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	signer, ok := c.resolveEnvelopeSigner(envHeader.KeyUID)
+	if !ok {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	if _, err := model.VerifyEncryptedPayload(header, body, signer); err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	hash, err := c.storeEncryptedAttachment(body)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"hash": hash,
+		"alg":  envHeader.Alg,
+	})
+}
+
+func (c *Core) attachmentDir(hash string) string {
+	return filepath.Join(c.dataDir, "attachments", hash[:2])
+}
+
+// storeEncryptedAttachment persists an encrypted blob on disk, content
+// addressed by sha256(ciphertext), without ever inspecting its contents.
+func (c *Core) storeEncryptedAttachment(body []byte) (string, error) {
+	sum := hashBytes(body)
+	dir := c.attachmentDir(sum)
+	if err := writeFileIfAbsent(dir, filepath.Join(dir, sum), body); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,30 +1,62 @@
 package core
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"image/gif"
-	"image/jpeg"
-	"image/png"
 	"io/ioutil"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/chanify/chanify/cache"
 	"github.com/chanify/chanify/crypto"
 	"github.com/chanify/chanify/model"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/image/tiff"
-	"golang.org/x/image/webp"
 )
 
-const (
-	pngHeader  = "\x89PNG\r\n\x1a\n"
-	gifHeader  = "GIF"
-	riffHeader = "RIFF"
-	webpHeader = "WEBP"
+// defaultCacheTTL is used for the token/key/thumbnail caches when a
+// CacheConfig doesn't override it.
+const defaultCacheTTL = 5 * time.Minute
+
+// CacheConfig configures the TTL LRU caches that sit in front of token
+// parsing, public key decoding, and thumbnail generation.
+type CacheConfig struct {
+	TTL  time.Duration
+	Size int
+}
+
+// these package-level caches are shared by the free functions below so
+// their signatures don't need to change to take a *Core.
+var (
+	tokenCache cache.Cache = cache.NewTTL(defaultCacheTTL, 4096)
+	keyCache   cache.Cache = cache.NewTTL(defaultCacheTTL, 4096)
+	thumbCache cache.Cache = cache.NewTTL(defaultCacheTTL, 1024)
 )
 
+type cachedThumbnail struct {
+	thumb       *model.Thumbnail
+	contentType string
+}
+
+// setupCache re-initializes the token/key/thumbnail caches from cfg.
+func setupCache(cfg *CacheConfig) {
+	ttl := defaultCacheTTL
+	size := 4096
+	if cfg != nil {
+		if cfg.TTL > 0 {
+			ttl = cfg.TTL
+		}
+		if cfg.Size > 0 {
+			size = cfg.Size
+		}
+	}
+	tokenCache = cache.NewTTL(ttl, size)
+	keyCache = cache.NewTTL(ttl, size)
+	thumbCache = cache.NewTTL(ttl, size)
+}
+
 func (c *Core) bindBodyJSON(ctx *gin.Context, obj interface{}) error {
 	body, err := ioutil.ReadAll(ctx.Request.Body)
 	if err != nil {
@@ -59,18 +91,61 @@ func verifyDevice(ctx *gin.Context, key string) bool {
 }
 
 func verifySign(key string, sign []byte, data []byte) bool {
-	kd, err := crypto.Base64Encode.DecodeString(key)
-	if err != nil {
-		return false
+	pk, ok := keyCache.Get(key)
+	if !ok {
+		kd, err := crypto.Base64Encode.DecodeString(key)
+		if err != nil {
+			return false
+		}
+		loaded, err := crypto.LoadPublicKey(kd)
+		if err != nil {
+			return false
+		}
+		pk = loaded
+		keyCache.Set(key, pk)
+	}
+	return pk.(crypto.PublicKey).Verify(data, sign)
+}
+
+// parseToken resolves token to a *model.Token, consulting tokenCache to
+// avoid re-parsing/re-verifying on every request. A cache hit is not
+// trusted blindly: ExpiresAt is re-checked against the current time, and
+// for the legacy signed-token format VerifyToken is re-run too, since it
+// may fold in revocation/device-state checks that aren't a pure function
+// of the parsed claims. JWS tokens skip that re-check because their
+// validity (claims signature, exp, iss, aud) was already fully
+// established by ParseJWSToken and doesn't change without re-parsing.
+// Either way, a token can never keep authenticating past its real
+// ExpiresAt just because it's still within the cache TTL.
+func (c *Core) parseToken(token string) (*model.Token, error) {
+	if tk, ok := tokenCache.Get(token); ok {
+		t := tk.(*model.Token)
+		if tokenExpired(t) {
+			tokenCache.Delete(token)
+			return nil, model.ErrInvalidToken
+		}
+		if !model.IsJWSToken(token) && !c.logic.VerifyToken(t) {
+			tokenCache.Delete(token)
+			return nil, model.ErrInvalidToken
+		}
+		return t, nil
 	}
-	pk, err := crypto.LoadPublicKey(kd)
+	tk, err := c.parseTokenUncached(token)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return pk.Verify(data, sign)
+	tokenCache.Set(token, tk)
+	return tk, nil
 }
 
-func (c *Core) parseToken(token string) (*model.Token, error) {
+func tokenExpired(tk *model.Token) bool {
+	return tk.ExpiresAt != 0 && uint64(time.Now().Unix()) >= tk.ExpiresAt
+}
+
+func (c *Core) parseTokenUncached(token string) (*model.Token, error) {
+	if model.IsJWSToken(token) {
+		return model.ParseJWSToken(token, c.logic.Keys())
+	}
 	tk, err := model.ParseToken(token)
 	if err != nil {
 		return nil, err
@@ -105,45 +180,41 @@ func parsePriority(priority string) int {
 }
 
 func parseImageContentType(data []byte) string {
-	if len(data) > 12 {
-		str := string(data[:12])
-		if strings.HasPrefix(str, pngHeader) {
-			return "image/png"
-		} else if strings.HasPrefix(str, gifHeader) {
-			return "image/gif"
-		} else if strings.HasPrefix(str, "\x49\x49") || strings.HasPrefix(str, "\x4D\x4D") {
-			return "image/tiff"
-		} else if strings.HasPrefix(str, riffHeader) && strings.HasPrefix(string(str[8:]), webpHeader) {
-			return "image/webp"
-		}
-	}
-	return "image/jpeg"
+	return model.ParseImageContentType(data)
 }
 
-func createThumbnail(data []byte) *model.Thumbnail {
-	switch parseImageContentType(data) {
-	case "image/png":
-		if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
-	case "image/gif":
-		if cfg, err := gif.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
-	case "image/tiff":
-		if cfg, err := tiff.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
-	case "image/webp":
-		if cfg, err := webp.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
-	default:
-		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(data)); err == nil {
-			return model.NewThumbnail(cfg.Width, cfg.Height)
-		}
+// createThumbnail returns a *model.Thumbnail plus its detected content
+// type for data, consulting (and populating) thumbCache keyed by
+// sha256(data) so repeated uploads of the same image skip both the
+// header decode and the content-type sniff.
+func createThumbnail(data []byte) (*model.Thumbnail, string) {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	if v, ok := thumbCache.Get(key); ok {
+		ct := v.(*cachedThumbnail)
+		return ct.thumb, ct.contentType
 	}
-	return nil
+	contentType := parseImageContentType(data)
+	thumb := model.NewThumbnailFromImage(data)
+	thumbCache.Set(key, &cachedThumbnail{thumb: thumb, contentType: contentType})
+	return thumb, contentType
+}
+
+// CacheMetrics reports cumulative hit/miss counts for the token, public
+// key, and thumbnail caches.
+type CacheMetrics struct {
+	TokenHits, TokenMisses uint64
+	KeyHits, KeyMisses     uint64
+	ThumbHits, ThumbMisses uint64
+}
+
+// cacheMetrics snapshots the current hit/miss counters.
+func cacheMetrics() CacheMetrics {
+	var m CacheMetrics
+	m.TokenHits, m.TokenMisses = tokenCache.Stats()
+	m.KeyHits, m.KeyMisses = keyCache.Stats()
+	m.ThumbHits, m.ThumbMisses = thumbCache.Stats()
+	return m
 }
 
 func fileBaseName(path string) string {
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"crypto/sha256"
+	"errors"
+)
+
+// Envelope header layout: 1-byte magic, 1-byte version, then an
+// algorithm-specific body. This lets future algorithms be added without
+// breaking older servers/clients.
+const (
+	envelopeMagic = 0xCE
+
+	// EnvelopeAlgECDSAP256 signs hash(ciphertext) || nonce || alg with an
+	// ECDSA-P256 key; the header carries a 12-byte nonce and an 8-byte kid.
+	EnvelopeAlgECDSAP256 = byte(1)
+	// EnvelopeAlgEd25519 signs the same payload with an Ed25519 key.
+	EnvelopeAlgEd25519 = byte(2)
+)
+
+// ErrInvalidEnvelope is returned when an encrypted attachment header is
+// malformed or fails signature verification.
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// EnvelopeHeader carries the metadata a client sends alongside an
+// already-encrypted attachment so the server can verify its origin
+// without ever decrypting the payload.
+type EnvelopeHeader struct {
+	Alg             byte
+	KeyUID          string
+	Nonce           []byte
+	EphemeralPubKey []byte
+	Signature       []byte
+}
+
+// ParseEnvelopeHeader decodes the TLV-ish envelope header prefix:
+//
+//	[0]      magic (0xCE)
+//	[1]      version/alg
+//	[2:10]   8-byte key id
+//	[10:n]   nonce (12 bytes)
+//	[n:m]    ephemeral public key (alg-dependent length)
+//	[m:]     signature (alg-dependent length)
+func ParseEnvelopeHeader(data []byte) (*EnvelopeHeader, error) {
+	if len(data) < 2 || data[0] != envelopeMagic {
+		return nil, ErrInvalidEnvelope
+	}
+	alg := data[1]
+	rest := data[2:]
+	if len(rest) < 8+12 {
+		return nil, ErrInvalidEnvelope
+	}
+	kid := rest[:8]
+	rest = rest[8:]
+	nonce := rest[:12]
+	rest = rest[12:]
+
+	var pubLen, sigLen int
+	switch alg {
+	case EnvelopeAlgECDSAP256:
+		pubLen, sigLen = 65, 64
+	case EnvelopeAlgEd25519:
+		pubLen, sigLen = 32, 64
+	default:
+		return nil, ErrInvalidEnvelope
+	}
+	if len(rest) != pubLen+sigLen {
+		return nil, ErrInvalidEnvelope
+	}
+	return &EnvelopeHeader{
+		Alg:             alg,
+		KeyUID:          string(kid),
+		Nonce:           nonce,
+		EphemeralPubKey: rest[:pubLen],
+		Signature:       rest[pubLen:],
+	}, nil
+}
+
+// signedPayload builds the bytes that were signed: hash(ciphertext) ||
+// nonce || alg.
+func (h *EnvelopeHeader) signedPayload(ciphertext []byte) []byte {
+	sum := sha256.Sum256(ciphertext)
+	out := make([]byte, 0, len(sum)+len(h.Nonce)+1)
+	out = append(out, sum[:]...)
+	out = append(out, h.Nonce...)
+	out = append(out, h.Alg)
+	return out
+}
+
+// EnvelopeVerifier verifies a signature for a given algorithm without
+// needing to know the signer's key type ahead of time.
+type EnvelopeVerifier interface {
+	Verify(data, sig []byte) bool
+}
+
+// VerifyEncryptedPayload checks that header's signature over
+// hash(body) || nonce || alg was produced by signerPubKey, without
+// decrypting body. It is the server-side counterpart to an end-to-end
+// encrypted attachment upload.
+func VerifyEncryptedPayload(header []byte, body []byte, signerPubKey EnvelopeVerifier) (*EnvelopeHeader, error) {
+	h, err := ParseEnvelopeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	if !signerPubKey.Verify(h.signedPayload(body), h.Signature) {
+		return nil, ErrInvalidEnvelope
+	}
+	return h, nil
+}
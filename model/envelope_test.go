@@ -0,0 +1,111 @@
+package model
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+// ecdsaP256Verifier verifies a fixed-size r||s ECDSA-P256 signature over
+// sha256(data), matching the envelope's "sign a hash" convention.
+type ecdsaP256Verifier struct {
+	pub *ecdsa.PublicKey
+}
+
+func (v ecdsaP256Verifier) Verify(data, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	digest := sha256.Sum256(data)
+	return ecdsa.Verify(v.pub, digest[:], r, s)
+}
+
+type ed25519Verifier struct {
+	pub ed25519.PublicKey
+}
+
+func (v ed25519Verifier) Verify(data, sig []byte) bool {
+	return ed25519.Verify(v.pub, data, sig)
+}
+
+func buildEnvelopeHeader(t *testing.T, alg byte, pub, nonce, sig []byte) []byte {
+	t.Helper()
+	header := []byte{envelopeMagic, alg}
+	header = append(header, []byte("kid-0001")...) // 8-byte key id
+	header = append(header, nonce...)
+	header = append(header, pub...)
+	header = append(header, sig...)
+	return header
+}
+
+func TestVerifyEncryptedPayloadECDSAP256(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	ciphertext := []byte("pretend-this-is-ciphertext")
+	nonce := make([]byte, 12)
+	for i := range nonce {
+		nonce[i] = byte(i)
+	}
+	h := &EnvelopeHeader{Alg: EnvelopeAlgECDSAP256, Nonce: nonce}
+	digest := sha256.Sum256(h.signedPayload(ciphertext))
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	pub := elliptic.Marshal(elliptic.P256(), priv.PublicKey.X, priv.PublicKey.Y)
+	header := buildEnvelopeHeader(t, EnvelopeAlgECDSAP256, pub, nonce, sig)
+
+	got, err := VerifyEncryptedPayload(header, ciphertext, ecdsaP256Verifier{pub: &priv.PublicKey})
+	if err != nil {
+		t.Fatalf("VerifyEncryptedPayload() error = %v", err)
+	}
+	if got.KeyUID != "kid-0001" {
+		t.Errorf("KeyUID = %q, want %q", got.KeyUID, "kid-0001")
+	}
+
+	tampered := append([]byte{}, ciphertext...)
+	tampered[0] ^= 0xFF
+	if _, err := VerifyEncryptedPayload(header, tampered, ecdsaP256Verifier{pub: &priv.PublicKey}); err != ErrInvalidEnvelope {
+		t.Errorf("VerifyEncryptedPayload() on tampered body error = %v, want ErrInvalidEnvelope", err)
+	}
+}
+
+func TestVerifyEncryptedPayloadEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	ciphertext := []byte("pretend-this-is-other-ciphertext")
+	nonce := make([]byte, 12)
+	for i := range nonce {
+		nonce[i] = byte(0x10 + i)
+	}
+	h := &EnvelopeHeader{Alg: EnvelopeAlgEd25519, Nonce: nonce}
+	sig := ed25519.Sign(priv, h.signedPayload(ciphertext))
+
+	header := buildEnvelopeHeader(t, EnvelopeAlgEd25519, pub, nonce, sig)
+
+	got, err := VerifyEncryptedPayload(header, ciphertext, ed25519Verifier{pub: pub})
+	if err != nil {
+		t.Fatalf("VerifyEncryptedPayload() error = %v", err)
+	}
+	if got.Alg != EnvelopeAlgEd25519 {
+		t.Errorf("Alg = %v, want %v", got.Alg, EnvelopeAlgEd25519)
+	}
+
+	if _, err := VerifyEncryptedPayload(header, append(ciphertext, 0x00), ed25519Verifier{pub: pub}); err != ErrInvalidEnvelope {
+		t.Errorf("VerifyEncryptedPayload() on tampered body error = %v, want ErrInvalidEnvelope", err)
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// IdentityImage wraps an uploaded avatar/icon (user, channel, or app) as
+// a reusable, content-addressed payload, replacing the ad-hoc handling
+// previously done inline by createThumbnail.
+type IdentityImage struct {
+	KeyUID       string // owning user/channel/app key
+	Name         string
+	Payload      []byte
+	Width        int
+	Height       int
+	FileSize     int
+	ResizeTarget int
+	Clock        uint64
+}
+
+// NewIdentityImage builds an IdentityImage from raw payload bytes,
+// deriving its dimensions via NewThumbnailFromImage.
+func NewIdentityImage(keyUID, name string, payload []byte, resizeTarget int, clock uint64) *IdentityImage {
+	img := &IdentityImage{
+		KeyUID:       keyUID,
+		Name:         name,
+		Payload:      payload,
+		FileSize:     len(payload),
+		ResizeTarget: resizeTarget,
+		Clock:        clock,
+	}
+	if thumb := NewThumbnailFromImage(payload); thumb != nil {
+		img.Width = int(thumb.Width)
+		img.Height = int(thumb.Height)
+	}
+	return img
+}
+
+// GetType returns the image's detected MIME type.
+func (img *IdentityImage) GetType() string {
+	return ParseImageContentType(img.Payload)
+}
+
+// Hash returns the content address of the image payload: the hex-encoded
+// SHA-256 digest used to dedupe identical uploads across users. This is
+// the only digest this package computes; there's no configurable
+// algorithm knob.
+func (img *IdentityImage) Hash() string {
+	sum := sha256.Sum256(img.Payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetDataURI returns the image as a data: URI so single-request avatar
+// fetches work for embedded UIs without a follow-up request.
+func (img *IdentityImage) GetDataURI() string {
+	return fmt.Sprintf("data:%s;base64,%s", img.GetType(), base64.StdEncoding.EncodeToString(img.Payload))
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"bytes"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
+)
+
+const (
+	pngHeader  = "\x89PNG\r\n\x1a\n"
+	gifHeader  = "GIF"
+	riffHeader = "RIFF"
+	webpHeader = "WEBP"
+)
+
+// ParseImageContentType sniffs the MIME type of an uploaded image from
+// its header bytes, defaulting to "image/jpeg".
+func ParseImageContentType(data []byte) string {
+	if len(data) > 12 {
+		str := string(data[:12])
+		if strings.HasPrefix(str, pngHeader) {
+			return "image/png"
+		} else if strings.HasPrefix(str, gifHeader) {
+			return "image/gif"
+		} else if strings.HasPrefix(str, "\x49\x49") || strings.HasPrefix(str, "\x4D\x4D") {
+			return "image/tiff"
+		} else if strings.HasPrefix(str, riffHeader) && strings.HasPrefix(string(str[8:]), webpHeader) {
+			return "image/webp"
+		}
+	}
+	return "image/jpeg"
+}
+
+// decodeImageSize returns the pixel dimensions of data, given its
+// already-detected content type.
+func decodeImageSize(contentType string, data []byte) (width, height int, ok bool) {
+	switch contentType {
+	case "image/png":
+		if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return cfg.Width, cfg.Height, true
+		}
+	case "image/gif":
+		if cfg, err := gif.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return cfg.Width, cfg.Height, true
+		}
+	case "image/tiff":
+		if cfg, err := tiff.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return cfg.Width, cfg.Height, true
+		}
+	case "image/webp":
+		if cfg, err := webp.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return cfg.Width, cfg.Height, true
+		}
+	default:
+		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return cfg.Width, cfg.Height, true
+		}
+	}
+	return 0, 0, false
+}
+
+// NewThumbnailFromImage builds a *Thumbnail directly from raw image
+// bytes, detecting its content type and dimensions in one step.
+func NewThumbnailFromImage(data []byte) *Thumbnail {
+	w, h, ok := decodeImageSize(ParseImageContentType(data), data)
+	if !ok {
+		return nil
+	}
+	return NewThumbnail(w, h)
+}
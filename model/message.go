@@ -64,9 +64,18 @@ func (m *Message) EncryptData(key []byte, ts uint64) []byte {
 
 	tag := key[32 : 32+32]
 	out := aesgcm.Seal(nil, nonce, m.Marshal(), tag)
+	if OnMessageSent != nil {
+		OnMessageSent(m)
+	}
 	return append(nonce, out...)
 }
 
+// OnMessageSent, when set, is invoked by EncryptData with every message
+// right after it is sealed for delivery. It lets subsystems such as the WebSocket
+// live-tail endpoint fan messages out without the model package knowing
+// about them.
+var OnMessageSent func(m *Message)
+
 func (m *Message) Marshal() []byte {
 	data, _ := proto.Marshal(&m.Message)
 	return data